@@ -7,184 +7,533 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/libdns/libdns"
 )
 
+// defaultHTTPClient is used when a Provider doesn't set HTTPClient. The
+// Bunny.net API has no documented SLA on response time, but a bounded
+// timeout keeps a slow or hung request from blocking a DNS-01 challenge
+// indefinitely.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// defaultRetryBackoff is the base delay used when a Provider doesn't set
+// RetryBackoff.
+const defaultRetryBackoff = 500 * time.Millisecond
+
+// maxRetryBackoff caps the exponential growth in retryBackoff so a large
+// MaxRetries/RetryBackoff combination can't overflow the backoff duration
+// and pass a zero or negative bound to rand.Int63n.
+const maxRetryBackoff = 2 * time.Minute
+
 type getAllRecordsResponse struct {
 	Records []bunnyRecord `json:"Records"`
+
+	// Nameserver1 and Nameserver2 are the zone's delegation nameservers.
+	// Bunny models these on the zone object rather than as records, so
+	// /dnszone/{id}/records never returns the apex NS records.
+	Nameserver1 string `json:"Nameserver1"`
+	Nameserver2 string `json:"Nameserver2"`
 }
 
 type getAllZonesResponse struct {
-	Zones []bunnyZone `json:"Items"`
+	Zones        []bunnyZone `json:"Items"`
+	HasMoreItems bool        `json:"HasMoreItems"`
 }
 
 type bunnyZone struct {
-	ID int `json:"Id"`
+	ID     int    `json:"Id"`
+	Domain string `json:"Domain"`
 }
 
+// zonePageSize is the page size used when paging through /dnszone; Bunny
+// caps this at 1000, but 100 keeps individual responses small while still
+// resolving most accounts in a single page.
+const zonePageSize = 100
+
 type bunnyRecord struct {
 	ID    int    `json:"Id,omitempty"`
 	Type  int    `json:"Type"`
 	Name  string `json:"Name"`
 	Value string `json:"Value"`
 	TTL   int    `json:"Ttl"`
+
+	// Priority and Weight only apply to MX and SRV records, and mirror
+	// libdns.Record's own Priority/Weight fields directly - unlike Port,
+	// Flags, and Tag below, they are never folded into Value. Port only
+	// applies to SRV.
+	Priority int `json:"Priority,omitempty"`
+	Weight   int `json:"Weight,omitempty"`
+	Port     int `json:"Port,omitempty"`
+
+	// Flags and Tag only apply to CAA records.
+	Flags int    `json:"Flags,omitempty"`
+	Tag   string `json:"Tag,omitempty"`
+
+	// Disabled is surfaced in ExportZone's JSON output but cannot be read
+	// or written through GetRecords/AppendRecords/SetRecords: libdns.Record
+	// has no field to hold it, so it can't be toggled through the
+	// libdns.Provider interface. updateRecord fetches and re-sends the
+	// current value (see recordDisabledCache) so a SetRecords call doesn't
+	// silently clear it; createRecord has nothing to preserve and always
+	// creates an enabled record.
+	Disabled bool `json:"Disabled,omitempty"`
 }
 
-func doRequest(accessKey string, request *http.Request) ([]byte, error) {
-	request.Header.Add("accept", "application/json")
-	request.Header.Add("AccessKey", accessKey)
+// toLibdnsValue builds the Value string libdns.Record expects. MX and SRV
+// carry Priority/Weight as dedicated libdns.Record fields (see
+// toLibdnsPriorityWeight), so Value only needs to hold what libdns.Record
+// has no field for: SRV's port, and CAA's flags+tag, packed in zone-file
+// order.
+func (r bunnyRecord) toLibdnsValue() string {
+	switch r.Type {
+	case bunnyTypeSRV:
+		return fmt.Sprintf("%d %s", r.Port, r.Value)
+	case bunnyTypeCAA:
+		return fmt.Sprintf("%d %s %s", r.Flags, r.Tag, r.Value)
+	default:
+		return r.Value
+	}
+}
 
-	client := &http.Client{}
-	response, err := client.Do(request)
-	if err != nil {
-		return nil, err
+// toLibdnsPriorityWeight returns the Priority/Weight to set on the
+// libdns.Record this bunnyRecord converts to. Only MX and SRV carry these.
+func (r bunnyRecord) toLibdnsPriorityWeight() (priority, weight uint) {
+	switch r.Type {
+	case bunnyTypeMX:
+		return uint(r.Priority), 0
+	case bunnyTypeSRV:
+		return uint(r.Priority), uint(r.Weight)
+	default:
+		return 0, 0
 	}
+}
 
-	if response.StatusCode < 200 || response.StatusCode >= 300 {
-		return nil, fmt.Errorf("%s (%d)", http.StatusText(response.StatusCode), response.StatusCode)
+// setFromLibdns fills in the dedicated Priority/Weight/Port/Flags/Tag fields
+// Bunny's API expects for MX, SRV, and CAA records from a libdns.Record,
+// along with the plain Value. MX and SRV carry Priority/Weight as dedicated
+// libdns.Record fields, which are copied directly rather than parsed out of
+// Value; Value is only split for the fields libdns.Record has no slot for
+// (SRV's port, CAA's flags+tag). Values with too few fields, or whose leading
+// port/flags field is not a number, are stored as-is, so malformed input
+// surfaces as a Bunny API error rather than a silent truncation here. Extra
+// fields are not rejected: everything after SRV's first field, or CAA's
+// second, is kept in Value.
+func (r *bunnyRecord) setFromLibdns(record libdns.Record) {
+	r.Value = record.Value
+
+	switch r.Type {
+	case bunnyTypeMX:
+		r.Priority = int(record.Priority)
+	case bunnyTypeSRV:
+		r.Priority = int(record.Priority)
+		r.Weight = int(record.Weight)
+		parts := strings.SplitN(record.Value, " ", 2)
+		if port, err := strconv.Atoi(parts[0]); len(parts) == 2 && err == nil {
+			r.Port = port
+			r.Value = parts[1]
+		}
+	case bunnyTypeCAA:
+		parts := strings.SplitN(record.Value, " ", 3)
+		if flags, err := strconv.Atoi(parts[0]); len(parts) == 3 && err == nil {
+			r.Flags = flags
+			r.Tag = parts[1]
+			r.Value = parts[2]
+		}
 	}
+}
 
-	defer response.Body.Close()
-	data, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+// doRequest issues method/requestURL (with an optional JSON body) against
+// the Bunny.net API, retrying on 429 and 5xx responses up to p.MaxRetries
+// times and honoring the rate limit configured via p.RequestsPerSecond. The
+// request is rebuilt on every attempt since sending a *http.Request consumes
+// its body.
+func doRequest(ctx context.Context, p *Provider, method string, requestURL string, body []byte) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		if err := p.rateLimiter().Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+
+		request, err := http.NewRequestWithContext(ctx, method, requestURL, bodyReader)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Add("accept", "application/json")
+		request.Header.Add("AccessKey", p.AccessKey)
+		if body != nil {
+			request.Header.Add("content-type", "application/json")
+		}
+
+		response, err := p.httpClient().Do(request)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if response.StatusCode >= 200 && response.StatusCode < 300 {
+			return data, nil
+		}
+
+		statusErr := fmt.Errorf("%s (%d)", http.StatusText(response.StatusCode), response.StatusCode)
+
+		retryable := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+		if !retryable || attempt >= p.MaxRetries {
+			return nil, statusErr
+		}
+
+		wait := retryAfter(response.Header)
+		if wait <= 0 {
+			wait = retryBackoff(p.RetryBackoff, attempt)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryAfter parses a Retry-After header (either delay-seconds or an
+// HTTP-date) into a duration, returning zero if the header is absent or
+// unparseable.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
 	}
 
-	return data, nil
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if when, err := http.ParseTime(value); err == nil {
+		return time.Until(when)
+	}
+
+	return 0
 }
 
-func getZoneID(ctx context.Context, zone string, accessKey string, domain string) (int, error) {
+// retryBackoff computes an exponential backoff with full jitter for the
+// given attempt (0-indexed), doubling base on each attempt up to
+// maxRetryBackoff.
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+
+	max := maxRetryBackoff
+	if attempt < 63 {
+		if shifted := base << attempt; shifted > 0 && shifted < max {
+			max = shifted
+		}
+	}
+
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// getZoneID resolves the Bunny zone ID for p.Zone (or domain, if p.Zone is
+// unset), paging through /dnszone and matching on an exact Domain equality
+// rather than the substring match the search endpoint performs. Results are
+// cached on p so repeated calls for the same zone don't pay a round-trip.
+func getZoneID(ctx context.Context, p *Provider, domain string) (int, error) {
 	param := domain
-	if len(zone) != 0 {
-		param = zone
+	if len(p.Zone) != 0 {
+		param = p.Zone
+	}
+
+	p.zoneIDsMu.Lock()
+	if id, ok := p.zoneIDs[param]; ok {
+		p.zoneIDsMu.Unlock()
+		return id, nil
+	}
+	p.zoneIDsMu.Unlock()
+
+	for page := 1; ; page++ {
+		requestURL := fmt.Sprintf("https://api.bunny.net/dnszone?page=%d&perPage=%d&search=%s", page, zonePageSize, url.QueryEscape(param))
+
+		data, err := doRequest(ctx, p, "GET", requestURL, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		result := getAllZonesResponse{}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return 0, err
+		}
+
+		for _, z := range result.Zones {
+			if z.Domain == param {
+				p.zoneIDsMu.Lock()
+				if p.zoneIDs == nil {
+					p.zoneIDs = make(map[string]int)
+				}
+				p.zoneIDs[param] = z.ID
+				p.zoneIDsMu.Unlock()
+
+				return z.ID, nil
+			}
+		}
+
+		if !result.HasMoreItems {
+			return 0, fmt.Errorf("unable to find zone %q", param)
+		}
 	}
+}
 
-	// [page => 1] and [perPage => 5] are the smallest accepted values for the API
-	req, err := http.NewRequestWithContext(ctx, "GET",
-		fmt.Sprintf("https://api.bunny.net/dnszone?page=1&perPage=5&search=%s", url.QueryEscape(param)), nil)
+// fetchZone resolves domain's zone and fetches its full GET /dnszone/{id}
+// response, which carries both the zone's records and its zone-level fields
+// (e.g. the delegation nameservers).
+func fetchZone(ctx context.Context, p *Provider, domain string) (getAllRecordsResponse, error) {
+	zoneID, err := getZoneID(ctx, p, domain)
 	if err != nil {
-		return 0, err
+		return getAllRecordsResponse{}, err
 	}
 
-	data, err := doRequest(accessKey, req)
+	data, err := doRequest(ctx, p, "GET", fmt.Sprintf("https://api.bunny.net/dnszone/%d", zoneID), nil)
 	if err != nil {
-		return 0, err
+		return getAllRecordsResponse{}, err
 	}
 
-	result := getAllZonesResponse{}
+	result := getAllRecordsResponse{}
 	if err := json.Unmarshal(data, &result); err != nil {
-		return 0, err
+		return getAllRecordsResponse{}, err
 	}
 
-	if len(result.Zones) > 1 {
-		return 0, errors.New("zone is ambiguous")
-	}
+	return result, nil
+}
+
+// recordDisabledCache resolves the current Disabled state of records in a
+// zone, fetching the zone's full record list at most once and reusing it for
+// the rest of a batch. updateRecord sends a full record representation
+// rather than a patch, so without this lookup an update made through
+// libdns.Record (which has no field for Disabled) would silently re-enable a
+// record disabled directly through Bunny's dashboard or API; fetching it
+// fresh per record would in turn make a SetRecords call with many records
+// (e.g. an initial zone sync) issue one full-zone GET per record updated.
+// The zero value is ready to use.
+type recordDisabledCache struct {
+	fetched bool
+	byID    map[string]bool
+}
+
+func (c *recordDisabledCache) disabled(ctx context.Context, p *Provider, domain string, id string) (bool, error) {
+	if !c.fetched {
+		result, err := fetchZone(ctx, p, domain)
+		if err != nil {
+			return false, err
+		}
 
-	if len(result.Zones) != 1 {
-		return 0, errors.New("unable to find zone")
+		c.byID = make(map[string]bool, len(result.Records))
+		for _, resData := range result.Records {
+			c.byID[fmt.Sprint(resData.ID)] = resData.Disabled
+		}
+		c.fetched = true
 	}
 
-	return result.Zones[0].ID, nil
+	return c.byID[id], nil
 }
 
-func getAllRecords(ctx context.Context, zone string, accessKey string, domain string) ([]libdns.Record, error) {
-	zoneID, err := getZoneID(ctx, zone, accessKey, domain)
+func getAllRecords(ctx context.Context, p *Provider, domain string) ([]libdns.Record, error) {
+	result, err := fetchZone(ctx, p, domain)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET",
-		fmt.Sprintf("https://api.bunny.net/dnszone/%d", zoneID), nil)
-	if err != nil {
-		return nil, err
+	records := []libdns.Record{}
+	for _, nameserver := range []string{result.Nameserver1, result.Nameserver2} {
+		if len(nameserver) == 0 {
+			continue
+		}
+		records = append(records, libdns.Record{
+			Type:  "NS",
+			Name:  "",
+			Value: nameserver,
+		})
+	}
+
+	for _, resData := range result.Records {
+		recordType, err := fromBunnyType(resData.Type)
+		if err != nil {
+			fmt.Printf("bunny: skipping record %d: %s\n", resData.ID, err)
+			continue
+		}
+
+		priority, weight := resData.toLibdnsPriorityWeight()
+		records = append(records, libdns.Record{
+			ID:       fmt.Sprint(resData.ID),
+			Type:     recordType,
+			Name:     resData.Name,
+			Value:    resData.toLibdnsValue(),
+			TTL:      time.Duration(resData.TTL) * time.Second,
+			Priority: priority,
+			Weight:   weight,
+		})
 	}
 
-	data, err := doRequest(accessKey, req)
+	return records, nil
+}
+
+// listAllZones pages through /dnszone and returns every zone the configured
+// API key can access.
+func listAllZones(ctx context.Context, p *Provider) ([]bunnyZone, error) {
+	var zones []bunnyZone
+
+	for page := 1; ; page++ {
+		requestURL := fmt.Sprintf("https://api.bunny.net/dnszone?page=%d&perPage=%d", page, zonePageSize)
+
+		data, err := doRequest(ctx, p, "GET", requestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		result := getAllZonesResponse{}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+
+		zones = append(zones, result.Zones...)
+
+		if !result.HasMoreItems {
+			return zones, nil
+		}
+	}
+}
+
+// exportRecord is the stable JSON shape ExportZone emits for each record in
+// a zone, including the Bunny-specific fields rich MX/SRV/CAA records carry.
+type exportRecord struct {
+	ID       int    `json:"id,omitempty"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	TTL      int    `json:"ttl,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+	Weight   int    `json:"weight,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Flags    int    `json:"flags,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// exportRecords fetches every record in domain's zone, including the
+// synthetic apex NS records, formatted for ExportZone's JSON output. Records
+// whose type fromBunnyType does not recognize are left out.
+func exportRecords(ctx context.Context, p *Provider, domain string) ([]exportRecord, error) {
+	result, err := fetchZone(ctx, p, domain)
 	if err != nil {
 		return nil, err
 	}
 
-	result := getAllRecordsResponse{}
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
+	records := []exportRecord{}
+	for _, nameserver := range []string{result.Nameserver1, result.Nameserver2} {
+		if len(nameserver) == 0 {
+			continue
+		}
+		records = append(records, exportRecord{Type: "NS", Name: "", Value: nameserver})
 	}
 
-	records := []libdns.Record{}
 	for _, resData := range result.Records {
-		records = append(records, libdns.Record{
-			ID:    fmt.Sprint(resData.ID),
-			Type:  fromBunnyType(resData.Type),
-			Name:  resData.Name,
-			Value: resData.Value,
-			TTL:   time.Duration(resData.TTL) * time.Second,
+		recordType, err := fromBunnyType(resData.Type)
+		if err != nil {
+			fmt.Printf("bunny: skipping record %d: %s\n", resData.ID, err)
+			continue
+		}
+
+		records = append(records, exportRecord{
+			ID:       resData.ID,
+			Type:     recordType,
+			Name:     resData.Name,
+			Value:    resData.Value,
+			TTL:      resData.TTL,
+			Priority: resData.Priority,
+			Weight:   resData.Weight,
+			Port:     resData.Port,
+			Flags:    resData.Flags,
+			Tag:      resData.Tag,
+			Disabled: resData.Disabled,
 		})
 	}
 
 	return records, nil
 }
 
-func createRecord(ctx context.Context, zone string, accessKey string, domain string, record libdns.Record) (libdns.Record, error) {
-	zoneID, err := getZoneID(ctx, zone, accessKey, domain)
+func createRecord(ctx context.Context, p *Provider, domain string, record libdns.Record) (libdns.Record, error) {
+	zoneID, err := getZoneID(ctx, p, domain)
+	if err != nil {
+		return libdns.Record{}, err
+	}
+
+	bunnyType, err := toBunnyType(record.Type)
 	if err != nil {
 		return libdns.Record{}, err
 	}
 
 	reqData := bunnyRecord{
-		Type:  toBunnyType(record.Type),
-		Name:  stripName(zone, domain, record.Name),
-		Value: record.Value,
-		TTL:   int(record.TTL.Seconds()),
+		Type: bunnyType,
+		Name: stripName(p.Zone, domain, record.Name),
+		TTL:  int(record.TTL.Seconds()),
 	}
+	reqData.setFromLibdns(record)
 
 	reqBuffer, err := json.Marshal(reqData)
 	if err != nil {
 		return libdns.Record{}, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "PUT",
-		fmt.Sprintf("https://api.bunny.net/dnszone/%d/records", zoneID), bytes.NewBuffer(reqBuffer))
+	data, err := doRequest(ctx, p, "PUT", fmt.Sprintf("https://api.bunny.net/dnszone/%d/records", zoneID), reqBuffer)
 	if err != nil {
 		return libdns.Record{}, err
 	}
 
-	req.Header.Add("content-type", "application/json")
-	data, err := doRequest(accessKey, req)
-	if err != nil {
+	result := bunnyRecord{}
+	if err := json.Unmarshal(data, &result); err != nil {
 		return libdns.Record{}, err
 	}
 
-	result := bunnyRecord{}
-	if err := json.Unmarshal(data, &result); err != nil {
+	recordType, err := fromBunnyType(result.Type)
+	if err != nil {
 		return libdns.Record{}, err
 	}
 
+	priority, weight := result.toLibdnsPriorityWeight()
 	return libdns.Record{
-		ID:    fmt.Sprint(result.ID),
-		Type:  fromBunnyType(result.Type),
-		Name:  libdns.RelativeName(result.Name, zone),
-		Value: result.Value,
-		TTL:   time.Duration(result.TTL) * time.Second,
+		ID:       fmt.Sprint(result.ID),
+		Type:     recordType,
+		Name:     libdns.RelativeName(result.Name, p.Zone),
+		Value:    result.toLibdnsValue(),
+		TTL:      time.Duration(result.TTL) * time.Second,
+		Priority: priority,
+		Weight:   weight,
 	}, nil
 }
 
-func deleteRecord(ctx context.Context, zone string, accessKey string, domain string, record libdns.Record) error {
-	zoneID, err := getZoneID(ctx, zone, accessKey, domain)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "DELETE",
-		fmt.Sprintf("https://api.bunny.net/dnszone/%d/records/%s", zoneID, url.PathEscape(record.ID)), nil)
+func deleteRecord(ctx context.Context, p *Provider, domain string, record libdns.Record) error {
+	zoneID, err := getZoneID(ctx, p, domain)
 	if err != nil {
 		return err
 	}
 
-	_, err = doRequest(accessKey, req)
+	_, err = doRequest(ctx, p, "DELETE", fmt.Sprintf("https://api.bunny.net/dnszone/%d/records/%s", zoneID, url.PathEscape(record.ID)), nil)
 	if err != nil {
 		return err
 	}
@@ -204,33 +553,36 @@ func stripName(zone string, domain string, name string) string {
 	return domain
 }
 
-func updateRecord(ctx context.Context, zone string, accessKey string, domain string, record libdns.Record) error {
-	zoneID, err := getZoneID(ctx, zone, accessKey, domain)
+func updateRecord(ctx context.Context, p *Provider, domain string, record libdns.Record, cache *recordDisabledCache) error {
+	zoneID, err := getZoneID(ctx, p, domain)
 	if err != nil {
 		return err
 	}
 
-	reqData := bunnyRecord{
-		Type:  toBunnyType(record.Type),
-		Name:  stripName(zone, domain, record.Name),
-		Value: record.Value,
-		TTL:   int(record.TTL.Seconds()),
+	bunnyType, err := toBunnyType(record.Type)
+	if err != nil {
+		return err
 	}
 
-	reqBuffer, err := json.Marshal(reqData)
+	disabled, err := cache.disabled(ctx, p, domain, record.ID)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST",
-		fmt.Sprintf("https://api.bunny.net/dnszone/%d/records/%s", zoneID, url.PathEscape(record.ID)), bytes.NewBuffer(reqBuffer))
+	reqData := bunnyRecord{
+		Type:     bunnyType,
+		Name:     stripName(p.Zone, domain, record.Name),
+		TTL:      int(record.TTL.Seconds()),
+		Disabled: disabled,
+	}
+	reqData.setFromLibdns(record)
+
+	reqBuffer, err := json.Marshal(reqData)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Add("content-type", "application/json")
-
-	_, err = doRequest(accessKey, req)
+	_, err = doRequest(ctx, p, "POST", fmt.Sprintf("https://api.bunny.net/dnszone/%d/records/%s", zoneID, url.PathEscape(record.ID)), reqBuffer)
 	if err != nil {
 		return err
 	}
@@ -239,12 +591,12 @@ func updateRecord(ctx context.Context, zone string, accessKey string, domain str
 }
 
 // Creates a new record if it does not exist, or updates an existing one.
-func createOrUpdateRecord(ctx context.Context, zone string, accessKey string, domain string, record libdns.Record) (libdns.Record, error) {
+func createOrUpdateRecord(ctx context.Context, p *Provider, domain string, record libdns.Record, cache *recordDisabledCache) (libdns.Record, error) {
 	if len(record.ID) == 0 {
-		return createRecord(ctx, zone, accessKey, domain, record)
+		return createRecord(ctx, p, domain, record)
 	}
 
-	err := updateRecord(ctx, zone, accessKey, domain, record)
+	err := updateRecord(ctx, p, domain, record, cache)
 	return record, err
 }
 
@@ -265,70 +617,76 @@ const (
 	bunnyTypeNS       = 12
 )
 
+// ErrUnsupportedRecordType is returned by fromBunnyType and toBunnyType when
+// asked to convert a record type neither side recognizes. Bunny periodically
+// adds new record types (e.g. Script, Flatten), so a long-running caller must
+// be able to handle this without crashing.
+var ErrUnsupportedRecordType = errors.New("bunny: unsupported record type")
+
 // Converts the Bunny.net record type to the libdns record type.
-func fromBunnyType(t int) string {
+func fromBunnyType(t int) (string, error) {
 	switch t {
 	case bunnyTypeA:
-		return "A"
+		return "A", nil
 	case bunnyTypeAAAA:
-		return "AAAA"
+		return "AAAA", nil
 	case bunnyTypeCNAME:
-		return "CNAME"
+		return "CNAME", nil
 	case bunnyTypeTXT:
-		return "TXT"
+		return "TXT", nil
 	case bunnyTypeMX:
-		return "MX"
+		return "MX", nil
 	case bunnyTypeRedirect:
-		return "Redirect"
+		return "Redirect", nil
 	case bunnyTypeFlatten:
-		return "Flatten"
+		return "Flatten", nil
 	case bunnyTypePullZone:
-		return "PullZone"
+		return "PullZone", nil
 	case bunnyTypeSRV:
-		return "SRV"
+		return "SRV", nil
 	case bunnyTypeCAA:
-		return "CAA"
+		return "CAA", nil
 	case bunnyTypePTR:
-		return "PTR"
+		return "PTR", nil
 	case bunnyTypeScript:
-		return "Script"
+		return "Script", nil
 	case bunnyTypeNS:
-		return "NS"
+		return "NS", nil
 	default:
-		panic(fmt.Sprintf("unknown record type: %d", t))
+		return "", fmt.Errorf("%w: %d", ErrUnsupportedRecordType, t)
 	}
 }
 
 // Converts the libdns record type to the Bunny.net record type.
-func toBunnyType(t string) int {
+func toBunnyType(t string) (int, error) {
 	switch t {
 	case "A":
-		return bunnyTypeA
+		return bunnyTypeA, nil
 	case "AAAA":
-		return bunnyTypeAAAA
+		return bunnyTypeAAAA, nil
 	case "CNAME":
-		return bunnyTypeCNAME
+		return bunnyTypeCNAME, nil
 	case "TXT":
-		return bunnyTypeTXT
+		return bunnyTypeTXT, nil
 	case "MX":
-		return bunnyTypeMX
+		return bunnyTypeMX, nil
 	case "Redirect":
-		return bunnyTypeRedirect
+		return bunnyTypeRedirect, nil
 	case "Flatten":
-		return bunnyTypeFlatten
+		return bunnyTypeFlatten, nil
 	case "PullZone":
-		return bunnyTypePullZone
+		return bunnyTypePullZone, nil
 	case "SRV":
-		return bunnyTypeSRV
+		return bunnyTypeSRV, nil
 	case "CAA":
-		return bunnyTypeCAA
+		return bunnyTypeCAA, nil
 	case "PTR":
-		return bunnyTypePTR
+		return bunnyTypePTR, nil
 	case "Script":
-		return bunnyTypeScript
+		return bunnyTypeScript, nil
 	case "NS":
-		return bunnyTypeNS
+		return bunnyTypeNS, nil
 	default:
-		panic(fmt.Sprintf("unknown record type: %s", t))
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedRecordType, t)
 	}
 }
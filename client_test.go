@@ -0,0 +1,73 @@
+package bunny
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestSetFromLibdnsAndToLibdnsRoundTrip(t *testing.T) {
+	cases := []struct {
+		name     string
+		rtype    int
+		value    string
+		priority uint
+		weight   uint
+	}{
+		{"MX", bunnyTypeMX, "mail.example.com.", 10, 0},
+		{"SRV", bunnyTypeSRV, "5060 sip.example.com.", 10, 20},
+		{"CAA", bunnyTypeCAA, "0 issue letsencrypt.org", 0, 0},
+		{"A", bunnyTypeA, "192.0.2.1", 0, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			record := libdns.Record{Value: c.value, Priority: c.priority, Weight: c.weight}
+
+			r := bunnyRecord{Type: c.rtype}
+			r.setFromLibdns(record)
+
+			if got := r.toLibdnsValue(); got != c.value {
+				t.Errorf("toLibdnsValue() = %q, want %q", got, c.value)
+			}
+
+			gotPriority, gotWeight := r.toLibdnsPriorityWeight()
+			if gotPriority != c.priority || gotWeight != c.weight {
+				t.Errorf("toLibdnsPriorityWeight() = (%d, %d), want (%d, %d)", gotPriority, gotWeight, c.priority, c.weight)
+			}
+		})
+	}
+}
+
+func TestFromBunnyTypeAndToBunnyTypeUnsupported(t *testing.T) {
+	if _, err := fromBunnyType(999); !errors.Is(err, ErrUnsupportedRecordType) {
+		t.Errorf("fromBunnyType(999) error = %v, want ErrUnsupportedRecordType", err)
+	}
+
+	if _, err := toBunnyType("BOGUS"); !errors.Is(err, ErrUnsupportedRecordType) {
+		t.Errorf("toBunnyType(%q) error = %v, want ErrUnsupportedRecordType", "BOGUS", err)
+	}
+}
+
+func TestSetFromLibdnsMalformed(t *testing.T) {
+	cases := []struct {
+		name  string
+		rtype int
+		value string
+	}{
+		{"SRV too few fields", bunnyTypeSRV, "sip.example.com."},
+		{"CAA non-numeric flags", bunnyTypeCAA, "x issue letsencrypt.org"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := bunnyRecord{Type: c.rtype}
+			r.setFromLibdns(libdns.Record{Value: c.value})
+
+			if r.Value != c.value {
+				t.Errorf("malformed value should be stored as-is: got Value %q, want %q", r.Value, c.value)
+			}
+		})
+	}
+}
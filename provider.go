@@ -2,10 +2,16 @@ package bunny
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/libdns/libdns"
+	"golang.org/x/time/rate"
 )
 
 // Provider facilitates DNS record manipulation with Bunny.net
@@ -13,11 +19,62 @@ type Provider struct {
 	// AccessKey is the Bunny.net API key - see https://docs.bunny.net/reference/bunnynet-api-overview
 	AccessKey string `json:"access_key"`
 	Zone      string `json:"zone"`
+
+	// HTTPClient is used for all requests to the Bunny.net API. If nil, a
+	// client with a default 30s timeout is used.
+	HTTPClient *http.Client `json:"-"`
+
+	// MaxRetries is the number of additional attempts made after a request
+	// fails with a 429 or 5xx response. Zero disables retries.
+	MaxRetries int `json:"max_retries,omitempty"`
+
+	// RetryBackoff is the base delay between retries; it doubles on each
+	// subsequent attempt with jitter applied, unless the API's Retry-After
+	// header says otherwise. Zero uses a 500ms base.
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
+	// RequestsPerSecond caps the rate of outgoing API requests. Zero means
+	// unlimited.
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty"`
+
+	// zoneIDs caches the Bunny zone ID resolved for each zone/domain lookup
+	// key, so repeated calls for the same zone don't each pay a round-trip
+	// to /dnszone.
+	zoneIDsMu sync.Mutex
+	zoneIDs   map[string]int
+
+	limiterOnce sync.Once
+	limiter     *rate.Limiter
+}
+
+// httpClient returns p.HTTPClient, falling back to a client with a bounded
+// timeout so a hung request can't block a DNS-01 challenge indefinitely.
+func (p *Provider) httpClient() *http.Client {
+	if p.HTTPClient != nil {
+		return p.HTTPClient
+	}
+
+	return defaultHTTPClient
+}
+
+// rateLimiter returns the Provider's request rate limiter, creating it on
+// first use from RequestsPerSecond. A Provider with no configured rate
+// allows requests through unthrottled.
+func (p *Provider) rateLimiter() *rate.Limiter {
+	p.limiterOnce.Do(func() {
+		limit := rate.Inf
+		if p.RequestsPerSecond > 0 {
+			limit = rate.Limit(p.RequestsPerSecond)
+		}
+		p.limiter = rate.NewLimiter(limit, 1)
+	})
+
+	return p.limiter
 }
 
 // GetRecords lists all the records in the zone.
 func (p *Provider) GetRecords(ctx context.Context, domain string) ([]libdns.Record, error) {
-	records, err := getAllRecords(ctx, p.Zone, p.AccessKey, unFQDN(domain))
+	records, err := getAllRecords(ctx, p, unFQDN(domain))
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +87,7 @@ func (p *Provider) AppendRecords(ctx context.Context, domain string, records []l
 	var appendedRecords []libdns.Record
 
 	for _, record := range records {
-		newRecord, err := createRecord(ctx, p.Zone, p.AccessKey, unFQDN(domain), record)
+		newRecord, err := createRecord(ctx, p, unFQDN(domain), record)
 		if err != nil {
 			return nil, err
 		}
@@ -41,12 +98,17 @@ func (p *Provider) AppendRecords(ctx context.Context, domain string, records []l
 }
 
 // SetRecords sets the records in the zone, either by updating existing records or creating new ones.
-// It returns the updated records.
+// It returns the updated records. libdns.Record has no field for Bunny's
+// "disabled" flag, so it can't be toggled through this method; an existing
+// record's disabled state is preserved rather than cleared on update.
 func (p *Provider) SetRecords(ctx context.Context, domain string, records []libdns.Record) ([]libdns.Record, error) {
+	domain = unFQDN(domain)
+
 	var setRecords []libdns.Record
+	cache := &recordDisabledCache{}
 
 	for _, record := range records {
-		setRecord, err := createOrUpdateRecord(ctx, p.Zone, p.AccessKey, unFQDN(domain), record)
+		setRecord, err := createOrUpdateRecord(ctx, p, domain, record, cache)
 		if err != nil {
 			return setRecords, err
 		}
@@ -61,7 +123,7 @@ func (p *Provider) DeleteRecords(ctx context.Context, domain string, records []l
 	var deletedRecords []libdns.Record
 
 	for _, record := range records {
-		err := deleteRecord(ctx, p.Zone, p.AccessKey, unFQDN(domain), record)
+		err := deleteRecord(ctx, p, unFQDN(domain), record)
 		if err != nil {
 			fmt.Println(err)
 		} else {
@@ -72,6 +134,37 @@ func (p *Provider) DeleteRecords(ctx context.Context, domain string, records []l
 	return deletedRecords, nil
 }
 
+// ListZones lists every zone the configured API key can access.
+func (p *Provider) ListZones(ctx context.Context) ([]libdns.Zone, error) {
+	zones, err := listAllZones(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]libdns.Zone, len(zones))
+	for i, zone := range zones {
+		result[i] = libdns.Zone{Name: zone.Domain}
+	}
+
+	return result, nil
+}
+
+// ExportZone writes every record in domain's zone to w as a stable,
+// indented JSON document - including the record ID, its extended
+// MX/SRV/CAA fields, and the synthetic apex NS records - so operators can
+// snapshot a Bunny zone for GitOps-style diffing. Records of a type this
+// package does not recognize are left out of the output.
+func (p *Provider) ExportZone(ctx context.Context, domain string, w io.Writer) error {
+	records, err := exportRecords(ctx, p, unFQDN(domain))
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
 // unFQDN trims any trailing "." from fqdn. Bunny.net's API does not use FQDNs.
 func unFQDN(fqdn string) string {
 	return strings.TrimSuffix(fqdn, ".")
@@ -83,4 +176,5 @@ var (
 	_ libdns.RecordAppender = (*Provider)(nil)
 	_ libdns.RecordSetter   = (*Provider)(nil)
 	_ libdns.RecordDeleter  = (*Provider)(nil)
+	_ libdns.ZoneLister     = (*Provider)(nil)
 )
@@ -0,0 +1,48 @@
+package bunny
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoffDoesNotPanic(t *testing.T) {
+	// A caller can legitimately configure a large MaxRetries, or a small
+	// MaxRetries with a larger custom RetryBackoff; either way base<<attempt
+	// must never overflow into a zero or negative bound for rand.Int63n.
+	attempts := []int{0, 1, 10, 35, 62, 63, 100, 1000}
+
+	for _, attempt := range attempts {
+		for _, base := range []time.Duration{0, defaultRetryBackoff, 5 * time.Minute} {
+			wait := retryBackoff(base, attempt)
+			if wait < 0 || wait > maxRetryBackoff {
+				t.Errorf("retryBackoff(%s, %d) = %s, want in [0, %s]", base, attempt, wait, maxRetryBackoff)
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{"absent", "", 0},
+		{"delay-seconds", "120", 120 * time.Second},
+		{"unparseable", "not-a-time", 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			header := http.Header{}
+			if c.header != "" {
+				header.Set("Retry-After", c.header)
+			}
+
+			if got := retryAfter(header); got != c.want {
+				t.Errorf("retryAfter(%q) = %s, want %s", c.header, got, c.want)
+			}
+		})
+	}
+}
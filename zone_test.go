@@ -0,0 +1,284 @@
+package bunny
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+// fakeRoundTripper serves canned JSON bodies keyed by "METHOD path?query", so
+// tests can exercise getZoneID/fetchZone's request-building logic without a
+// real Bunny.net API.
+type fakeRoundTripper struct {
+	t         *testing.T
+	responses map[string]string
+	calls     []string
+}
+
+func (f *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := fmt.Sprintf("%s %s?%s", req.Method, req.URL.Path, req.URL.RawQuery)
+	f.calls = append(f.calls, key)
+
+	body, ok := f.responses[key]
+	if !ok {
+		f.t.Fatalf("unexpected request: %s", key)
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+	}, nil
+}
+
+func newTestProvider(rt *fakeRoundTripper) *Provider {
+	return &Provider{
+		AccessKey:  "test",
+		HTTPClient: &http.Client{Transport: rt},
+	}
+}
+
+func TestGetZoneIDPaginatesAndCaches(t *testing.T) {
+	rt := &fakeRoundTripper{
+		t: t,
+		responses: map[string]string{
+			"GET /dnszone?page=1&perPage=100&search=example.com": `{"Items":[{"Id":1,"Domain":"notexample.com"}],"HasMoreItems":true}`,
+			"GET /dnszone?page=2&perPage=100&search=example.com": `{"Items":[{"Id":2,"Domain":"example.com"}],"HasMoreItems":false}`,
+		},
+	}
+	p := newTestProvider(rt)
+
+	id, err := getZoneID(context.Background(), p, "example.com")
+	if err != nil {
+		t.Fatalf("getZoneID: %v", err)
+	}
+	if id != 2 {
+		t.Errorf("getZoneID = %d, want 2 (page 1's substring match must not be returned)", id)
+	}
+	if len(rt.calls) != 2 {
+		t.Errorf("expected 2 requests (one per page), got %d: %v", len(rt.calls), rt.calls)
+	}
+
+	if _, err := getZoneID(context.Background(), p, "example.com"); err != nil {
+		t.Fatalf("getZoneID (cached): %v", err)
+	}
+	if len(rt.calls) != 2 {
+		t.Errorf("cached call made a new request, got %d total: %v", len(rt.calls), rt.calls)
+	}
+}
+
+func TestGetZoneIDExactMatchDisambiguation(t *testing.T) {
+	rt := &fakeRoundTripper{
+		t: t,
+		responses: map[string]string{
+			"GET /dnszone?page=1&perPage=100&search=example.com": `{"Items":[{"Id":5,"Domain":"notexample.com"},{"Id":6,"Domain":"example.com"}],"HasMoreItems":false}`,
+		},
+	}
+	p := newTestProvider(rt)
+
+	id, err := getZoneID(context.Background(), p, "example.com")
+	if err != nil {
+		t.Fatalf("getZoneID: %v", err)
+	}
+	if id != 6 {
+		t.Errorf("getZoneID = %d, want 6 (exact Domain match, not the substring match)", id)
+	}
+}
+
+func TestGetZoneIDNotFound(t *testing.T) {
+	rt := &fakeRoundTripper{
+		t: t,
+		responses: map[string]string{
+			"GET /dnszone?page=1&perPage=100&search=example.com": `{"Items":[],"HasMoreItems":false}`,
+		},
+	}
+	p := newTestProvider(rt)
+
+	if _, err := getZoneID(context.Background(), p, "example.com"); err == nil {
+		t.Error("getZoneID: expected an error when no zone matches, got nil")
+	}
+}
+
+func TestListAllZonesPaginates(t *testing.T) {
+	rt := &fakeRoundTripper{
+		t: t,
+		responses: map[string]string{
+			"GET /dnszone?page=1&perPage=100": `{"Items":[{"Id":1,"Domain":"a.com"}],"HasMoreItems":true}`,
+			"GET /dnszone?page=2&perPage=100": `{"Items":[{"Id":2,"Domain":"b.com"}],"HasMoreItems":false}`,
+		},
+	}
+	p := newTestProvider(rt)
+
+	zones, err := listAllZones(context.Background(), p)
+	if err != nil {
+		t.Fatalf("listAllZones: %v", err)
+	}
+
+	want := []bunnyZone{{ID: 1, Domain: "a.com"}, {ID: 2, Domain: "b.com"}}
+	if len(zones) != len(want) {
+		t.Fatalf("got %d zones, want %d: %v", len(zones), len(want), zones)
+	}
+	for i, z := range zones {
+		if z != want[i] {
+			t.Errorf("zones[%d] = %+v, want %+v", i, z, want[i])
+		}
+	}
+}
+
+const apexFixture = `{"Records":[{"Id":1,"Type":3,"Name":"www","Value":"hello","Ttl":300}],"Nameserver1":"ns1.bunny.net","Nameserver2":"ns2.bunny.net"}`
+
+func TestGetAllRecordsIncludesApexNS(t *testing.T) {
+	rt := &fakeRoundTripper{
+		t: t,
+		responses: map[string]string{
+			"GET /dnszone?page=1&perPage=100&search=example.com": `{"Items":[{"Id":42,"Domain":"example.com"}],"HasMoreItems":false}`,
+			"GET /dnszone/42?": apexFixture,
+		},
+	}
+	p := newTestProvider(rt)
+
+	records, err := getAllRecords(context.Background(), p, "example.com")
+	if err != nil {
+		t.Fatalf("getAllRecords: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3 (2 apex NS + 1 real record)", len(records))
+	}
+
+	var nsCount int
+	for _, r := range records {
+		if r.Type != "NS" {
+			continue
+		}
+		nsCount++
+		if r.Name != "" {
+			t.Errorf("synthetic NS record Name = %q, want empty (apex)", r.Name)
+		}
+	}
+	if nsCount != 2 {
+		t.Errorf("got %d NS records, want 2 (one per configured nameserver)", nsCount)
+	}
+}
+
+func TestGetAllRecordsSkipsUnsupportedType(t *testing.T) {
+	rt := &fakeRoundTripper{
+		t: t,
+		responses: map[string]string{
+			"GET /dnszone?page=1&perPage=100&search=example.com": `{"Items":[{"Id":42,"Domain":"example.com"}],"HasMoreItems":false}`,
+			"GET /dnszone/42?": `{"Records":[{"Id":1,"Type":999,"Name":"weird","Value":"?","Ttl":300},{"Id":2,"Type":3,"Name":"www","Value":"hello","Ttl":300}]}`,
+		},
+	}
+	p := newTestProvider(rt)
+
+	records, err := getAllRecords(context.Background(), p, "example.com")
+	if err != nil {
+		t.Fatalf("getAllRecords: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1 (the unsupported type skipped, not aborted)", len(records))
+	}
+	if records[0].Name != "www" {
+		t.Errorf("got record %q, want the surviving record %q", records[0].Name, "www")
+	}
+}
+
+func TestExportRecordsIncludesApexNSOnce(t *testing.T) {
+	rt := &fakeRoundTripper{
+		t: t,
+		responses: map[string]string{
+			"GET /dnszone?page=1&perPage=100&search=example.com": `{"Items":[{"Id":42,"Domain":"example.com"}],"HasMoreItems":false}`,
+			"GET /dnszone/42?": apexFixture,
+		},
+	}
+	p := newTestProvider(rt)
+
+	records, err := exportRecords(context.Background(), p, "example.com")
+	if err != nil {
+		t.Fatalf("exportRecords: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3 (2 apex NS + 1 real record)", len(records))
+	}
+
+	var nsRecords []exportRecord
+	for _, r := range records {
+		if r.Type == "NS" {
+			nsRecords = append(nsRecords, r)
+		}
+	}
+	if len(nsRecords) != 2 {
+		t.Fatalf("got %d NS records, want 2", len(nsRecords))
+	}
+	for _, r := range nsRecords {
+		if r.Name != "" {
+			t.Errorf("synthetic NS record Name = %q, want empty (apex)", r.Name)
+		}
+	}
+}
+
+func TestCreateAndUpdateRecordRejectUnsupportedType(t *testing.T) {
+	rt := &fakeRoundTripper{
+		t: t,
+		responses: map[string]string{
+			"GET /dnszone?page=1&perPage=100&search=example.com": `{"Items":[{"Id":42,"Domain":"example.com"}],"HasMoreItems":false}`,
+		},
+	}
+	p := newTestProvider(rt)
+	record := libdns.Record{ID: "1", Type: "BOGUS", Value: "hello"}
+
+	if _, err := createRecord(context.Background(), p, "example.com", record); !errors.Is(err, ErrUnsupportedRecordType) {
+		t.Errorf("createRecord error = %v, want ErrUnsupportedRecordType", err)
+	}
+
+	if err := updateRecord(context.Background(), p, "example.com", record, &recordDisabledCache{}); !errors.Is(err, ErrUnsupportedRecordType) {
+		t.Errorf("updateRecord error = %v, want ErrUnsupportedRecordType", err)
+	}
+
+	for _, call := range rt.calls {
+		if strings.HasPrefix(call, "GET /dnszone/42") {
+			t.Errorf("unexpected record fetch %q: toBunnyType should fail before any per-record request", call)
+		}
+	}
+}
+
+func TestExportZoneJSON(t *testing.T) {
+	rt := &fakeRoundTripper{
+		t: t,
+		responses: map[string]string{
+			"GET /dnszone?page=1&perPage=100&search=example.com": `{"Items":[{"Id":42,"Domain":"example.com"}],"HasMoreItems":false}`,
+			"GET /dnszone/42?": `{"Records":[{"Id":1,"Type":4,"Name":"","Value":"mail.example.com","Ttl":300,"Priority":10,"Disabled":true}],"Nameserver1":"ns1.bunny.net","Nameserver2":"ns2.bunny.net"}`,
+		},
+	}
+	p := newTestProvider(rt)
+
+	var buf bytes.Buffer
+	if err := p.ExportZone(context.Background(), "example.com", &buf); err != nil {
+		t.Fatalf("ExportZone: %v", err)
+	}
+
+	var records []exportRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("ExportZone output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3 (2 apex NS + 1 MX record)", len(records))
+	}
+
+	mx := records[2]
+	want := exportRecord{ID: 1, Type: "MX", Name: "", Value: "mail.example.com", TTL: 300, Priority: 10, Disabled: true}
+	if mx != want {
+		t.Errorf("MX record = %+v, want %+v", mx, want)
+	}
+
+	if !strings.Contains(buf.String(), `"disabled": true`) {
+		t.Errorf("ExportZone output missing expected field name %q:\n%s", "disabled", buf.String())
+	}
+}